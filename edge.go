@@ -0,0 +1,205 @@
+package ridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// RequestEdge represents a CloudFront Lambda@Edge viewer-request or
+// origin-request event.
+// https://docs.aws.amazon.com/AmazonCloudFront/latest/DeveloperGuide/lambda-event-structure.html
+type RequestEdge struct {
+	Records []RequestEdgeRecord `json:"Records"`
+}
+
+// RequestEdgeRecord wraps the single CloudFront event delivered in a
+// Lambda@Edge invocation.
+type RequestEdgeRecord struct {
+	CF CFEvent `json:"cf"`
+}
+
+// CFEvent is the CloudFront event envelope for a Lambda@Edge invocation.
+type CFEvent struct {
+	Config  CFConfig  `json:"config"`
+	Request CFRequest `json:"request"`
+}
+
+// CFConfig describes the CloudFront distribution and trigger that invoked
+// the function.
+type CFConfig struct {
+	DistributionDomainName string `json:"distributionDomainName"`
+	DistributionID         string `json:"distributionId"`
+	EventType              string `json:"eventType"`
+	RequestID              string `json:"requestId"`
+}
+
+// CFRequest is the viewer-request/origin-request payload CloudFront passes
+// to the function.
+type CFRequest struct {
+	ClientIP    string                `json:"clientIp"`
+	Method      string                `json:"method"`
+	URI         string                `json:"uri"`
+	QueryString string                `json:"querystring"`
+	Headers     map[string][]CFHeader `json:"headers"`
+	Body        *CFBody               `json:"body,omitempty"`
+}
+
+// CFHeader is a single CloudFront header value; CloudFront represents
+// headers as a map of lowercased header name to a list of these.
+type CFHeader struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// CFBody carries the request body CloudFront forwarded to the function.
+type CFBody struct {
+	Data           string `json:"data"`
+	Encoding       string `json:"encoding"`
+	InputTruncated bool   `json:"inputTruncated"`
+}
+
+type edgeContextKey struct{}
+
+// EdgeInputTruncated reports whether CloudFront truncated the request body
+// before invoking the function, as recorded in the event's
+// request.body.inputTruncated field. Handlers can use this to respond
+// 413 Request Entity Too Large instead of processing a partial body.
+func EdgeInputTruncated(r *http.Request) bool {
+	truncated, _ := r.Context().Value(edgeContextKey{}).(bool)
+	return truncated
+}
+
+func (r RequestEdge) httpRequest() (*http.Request, error) {
+	if len(r.Records) == 0 {
+		return nil, fmt.Errorf("cloudfront event has no Records")
+	}
+	cf := r.Records[0].CF.Request
+
+	header := make(http.Header)
+	for _, values := range cf.Headers {
+		for _, h := range values {
+			header.Add(h.Key, h.Value)
+		}
+	}
+	host := header.Get("Host")
+	header.Del("Host")
+
+	decodedPath, rawPath, err := escapePath(cf.URI)
+	if err != nil {
+		return nil, err
+	}
+	uri := rawPath
+	if cf.QueryString != "" {
+		uri = uri + "?" + cf.QueryString
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = decodedPath
+	u.RawPath = rawPath
+
+	var contentLength int64
+	var b io.Reader = bytes.NewReader(nil)
+	var inputTruncated bool
+	if cf.Body != nil {
+		inputTruncated = cf.Body.InputTruncated
+		switch cf.Body.Encoding {
+		case "base64":
+			raw, err := base64.StdEncoding.DecodeString(cf.Body.Data)
+			if err != nil {
+				return nil, err
+			}
+			contentLength = int64(len(raw))
+			b = bytes.NewReader(raw)
+		default:
+			contentLength = int64(len(cf.Body.Data))
+			b = strings.NewReader(cf.Body.Data)
+		}
+	}
+
+	req := (&http.Request{
+		Method:        cf.Method,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		ContentLength: contentLength,
+		Body:          ioutil.NopCloser(b),
+		RemoteAddr:    cf.ClientIP,
+		Host:          host,
+		RequestURI:    uri,
+		URL:           u,
+	}).WithContext(context.WithValue(context.Background(), edgeContextKey{}, inputTruncated))
+	return req, nil
+}
+
+// CFResponse is the response payload a Lambda@Edge viewer-request /
+// origin-request function returns to CloudFront in place of the request.
+type CFResponse struct {
+	Status            string                `json:"status"`
+	StatusDescription string                `json:"statusDescription,omitempty"`
+	Headers           map[string][]CFHeader `json:"headers,omitempty"`
+	Body              string                `json:"body,omitempty"`
+	BodyEncoding      string                `json:"bodyEncoding,omitempty"`
+}
+
+// WriteEdgeResponse converts resp into the CloudFront response shape a
+// Lambda@Edge function returns to short-circuit the request. Binary content
+// types are base64-encoded; everything else is returned as plain text.
+func WriteEdgeResponse(resp *http.Response) (*CFResponse, error) {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string][]CFHeader, len(resp.Header))
+	for key, values := range resp.Header {
+		lower := strings.ToLower(key)
+		for _, value := range values {
+			headers[lower] = append(headers[lower], CFHeader{Key: key, Value: value})
+		}
+	}
+
+	out := &CFResponse{
+		Status:            strconv.Itoa(resp.StatusCode),
+		StatusDescription: http.StatusText(resp.StatusCode),
+		Headers:           headers,
+	}
+	if isBinaryContentType(resp.Header.Get("Content-Type")) {
+		out.Body = base64.StdEncoding.EncodeToString(body)
+		out.BodyEncoding = "base64"
+	} else {
+		out.Body = string(body)
+	}
+	return out, nil
+}
+
+// isBinaryContentType reports whether content, a Content-Type header value,
+// identifies data that must be base64-encoded rather than sent as text.
+func isBinaryContentType(content string) bool {
+	mediaType := strings.ToLower(strings.SplitN(content, ";", 2)[0])
+	mediaType = strings.TrimSpace(mediaType)
+	switch {
+	case mediaType == "":
+		return false
+	case strings.HasPrefix(mediaType, "text/"):
+		return false
+	case strings.Contains(mediaType, "json"),
+		strings.Contains(mediaType, "xml"),
+		strings.Contains(mediaType, "javascript"),
+		strings.Contains(mediaType, "x-www-form-urlencoded"):
+		return false
+	default:
+		return true
+	}
+}
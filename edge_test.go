@@ -0,0 +1,73 @@
+package ridge
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func newEdgeEvent(uri string) RequestEdge {
+	return RequestEdge{
+		Records: []RequestEdgeRecord{
+			{
+				CF: CFEvent{
+					Request: CFRequest{
+						ClientIP: "203.0.113.1",
+						Method:   "GET",
+						URI:      uri,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRequestEdgePathEscaping(t *testing.T) {
+	tests := []struct {
+		name            string
+		uri             string
+		wantPath        string
+		wantEscapedPath string
+	}{
+		{
+			name:            "encoded slash",
+			uri:             "/users/foo%2Fbar",
+			wantPath:        "/users/foo/bar",
+			wantEscapedPath: "/users/foo%2Fbar",
+		},
+		{
+			name:            "plain",
+			uri:             "/users/foo",
+			wantPath:        "/users/foo",
+			wantEscapedPath: "/users/foo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := json.Marshal(newEdgeEvent(tt.uri))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, err := NewRequest(event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := req.URL.Path; got != tt.wantPath {
+				t.Errorf("URL.Path = %q, want %q", got, tt.wantPath)
+			}
+			if got := req.URL.EscapedPath(); got != tt.wantEscapedPath {
+				t.Errorf("URL.EscapedPath() = %q, want %q", got, tt.wantEscapedPath)
+			}
+		})
+	}
+}
+
+func TestRequestEdgeInvalidPathReturnsError(t *testing.T) {
+	event, err := json.Marshal(newEdgeEvent("/users/%zz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRequest(event); err == nil {
+		t.Error("expected an error for a malformed percent-escape, got nil")
+	}
+}
@@ -38,7 +38,15 @@ type Request = RequestV1
 // NewRequest creates *net/http.Request from a Request.
 func NewRequest(event json.RawMessage) (*http.Request, error) {
 	var r struct {
-		Version string `json:"version"`
+		Version        string `json:"version"`
+		RequestContext struct {
+			ELB *struct {
+				TargetGroupArn string `json:"targetGroupArn"`
+			} `json:"elb"`
+		} `json:"requestContext"`
+		Records []struct {
+			CF json.RawMessage `json:"cf"`
+		} `json:"Records"`
 	}
 	if PayloadVersion == "" {
 		if err := json.Unmarshal(event, &r); err != nil {
@@ -55,7 +63,27 @@ func NewRequest(event json.RawMessage) (*http.Request, error) {
 			return nil, err
 		}
 		return rv2.httpRequest()
-	case "1.0", "":
+	case "1.0":
+		var rv1 RequestV1
+		if err := json.Unmarshal(event, &rv1); err != nil {
+			return nil, err
+		}
+		return rv1.httpRequest()
+	case "":
+		if len(r.Records) > 0 && r.Records[0].CF != nil {
+			var redge RequestEdge
+			if err := json.Unmarshal(event, &redge); err != nil {
+				return nil, err
+			}
+			return redge.httpRequest()
+		}
+		if r.RequestContext.ELB != nil {
+			var ralb RequestALB
+			if err := json.Unmarshal(event, &ralb); err != nil {
+				return nil, err
+			}
+			return ralb.httpRequest()
+		}
 		var rv1 RequestV1
 		if err := json.Unmarshal(event, &rv1); err != nil {
 			return nil, err
@@ -93,11 +121,20 @@ func (r RequestV1) httpRequest() (*http.Request, error) {
 			v.Add(key, value)
 		}
 	}
-	uri := r.Path
+	decodedPath, rawPath, err := escapePath(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	uri := rawPath
 	if len(r.QueryStringParameters) > 0 {
 		uri = uri + "?" + v.Encode()
 	}
-	u, _ := url.Parse(uri)
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = decodedPath
+	u.RawPath = rawPath
 	var contentLength int64
 	var b io.Reader
 	if r.IsBase64Encoded {
@@ -128,6 +165,28 @@ func (r RequestV1) httpRequest() (*http.Request, error) {
 	return &req, nil
 }
 
+// escapePath splits path on its literal "/" separators (never on an escaped
+// "%2F", which stays inside the segment it belongs to) and re-encodes each
+// segment per the AWS REST escaping table (unreserved set A-Z a-z 0-9 - . _
+// ~, everything else percent-encoded as uppercase hex). It returns both the
+// fully decoded path and its normalized escaped form, so a percent-encoded
+// slash a client sent as "%2F" survives as "%2F" in the escaped form instead
+// of being indistinguishable from a literal path separator.
+func escapePath(path string) (decoded, escaped string, err error) {
+	segments := strings.Split(path, "/")
+	decodedSegments := make([]string, len(segments))
+	escapedSegments := make([]string, len(segments))
+	for i, seg := range segments {
+		d, err := url.PathUnescape(seg)
+		if err != nil {
+			return "", "", err
+		}
+		decodedSegments[i] = d
+		escapedSegments[i] = awsEscape(d)
+	}
+	return strings.Join(decodedSegments, "/"), strings.Join(escapedSegments, "/"), nil
+}
+
 // RequestContextV1 represents request contest object (v1.0).
 type RequestContextV1 struct {
 	AccountID    string            `json:"accountId"`
@@ -180,6 +239,114 @@ type RequestContextV2 struct {
 	TimeEpoch int64  `json:"timeEpoch"`
 }
 
+// RequestALB represents an HTTP request received by an Application Load
+// Balancer Lambda target group integration.
+// https://docs.aws.amazon.com/elasticloadbalancing/latest/application/lambda-functions.html
+// MultiValueHeaders and MultiValueQueryStringParameters are only populated
+// when the target group has "Multi value headers" enabled; the single-valued
+// fields are used otherwise. The two shapes are never mixed.
+type RequestALB struct {
+	HTTPMethod                      string              `json:"httpMethod"`
+	Path                            string              `json:"path"`
+	Body                            string              `json:"body"`
+	IsBase64Encoded                 bool                `json:"isBase64Encoded"`
+	Headers                         map[string]string   `json:"headers"`
+	MultiValueHeaders               http.Header         `json:"multiValueHeaders"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+	RequestContext                  RequestContextALB   `json:"requestContext"`
+}
+
+// RequestContextALB represents the request context of an ALB target group
+// invocation.
+type RequestContextALB struct {
+	ELB struct {
+		TargetGroupArn string `json:"targetGroupArn"`
+	} `json:"elb"`
+}
+
+func (r RequestALB) httpRequest() (*http.Request, error) {
+	header := make(http.Header)
+	if len(r.MultiValueHeaders) > 0 {
+		for key, values := range r.MultiValueHeaders {
+			for _, value := range values {
+				header.Add(key, value)
+			}
+		}
+	} else {
+		for key, value := range r.Headers {
+			header.Add(key, value)
+		}
+	}
+	host := header.Get("Host")
+	header.Del("Host")
+	v := make(url.Values)
+	if len(r.MultiValueQueryStringParameters) > 0 {
+		for key, values := range r.MultiValueQueryStringParameters {
+			for _, value := range values {
+				v.Add(key, value)
+			}
+		}
+	} else {
+		for key, value := range r.QueryStringParameters {
+			v.Add(key, value)
+		}
+	}
+	decodedPath, rawPath, err := escapePath(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	uri := rawPath
+	if len(v) > 0 {
+		uri = uri + "?" + v.Encode()
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = decodedPath
+	u.RawPath = rawPath
+	var contentLength int64
+	var b io.Reader
+	if r.IsBase64Encoded {
+		raw := make([]byte, len(r.Body))
+		n, err := base64.StdEncoding.Decode(raw, []byte(r.Body))
+		if err != nil {
+			return nil, err
+		}
+		contentLength = int64(n)
+		b = bytes.NewReader(raw[0:n])
+	} else {
+		contentLength = int64(len(r.Body))
+		b = strings.NewReader(r.Body)
+	}
+	req := http.Request{
+		Method:        r.HTTPMethod,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		ContentLength: contentLength,
+		Body:          ioutil.NopCloser(b),
+		RemoteAddr:    header.Get("X-Forwarded-For"),
+		Host:          host,
+		RequestURI:    uri,
+		URL:           u,
+	}
+	return &req, nil
+}
+
+// ResponseALB represents the response payload an ALB target group
+// integration expects back from the Lambda function.
+type ResponseALB struct {
+	StatusCode        int                 `json:"statusCode"`
+	StatusDescription string              `json:"statusDescription"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
 func (r RequestV2) httpRequest() (*http.Request, error) {
 	header := make(http.Header)
 	for key, value := range r.Headers {
@@ -187,11 +354,20 @@ func (r RequestV2) httpRequest() (*http.Request, error) {
 	}
 	host := header.Get("Host")
 	header.Del("Host")
+	decodedPath, _, err := escapePath(r.RawPath)
+	if err != nil {
+		return nil, err
+	}
 	uri := r.RawPath
 	if r.RawQueryString != "" {
 		uri = uri + "?" + r.RawQueryString
 	}
-	u, _ := url.Parse(uri)
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = decodedPath
+	u.RawPath = r.RawPath
 	var contentLength int64
 	var b io.Reader
 	if r.IsBase64Encoded {
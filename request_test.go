@@ -0,0 +1,204 @@
+package ridge
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRequestV1PathEscaping(t *testing.T) {
+	tests := []struct {
+		name            string
+		path            string
+		wantPath        string
+		wantRawPath     string
+		wantEscapedPath string
+	}{
+		{
+			name:            "encoded slash",
+			path:            "/users/foo%2Fbar",
+			wantPath:        "/users/foo/bar",
+			wantRawPath:     "/users/foo%2Fbar",
+			wantEscapedPath: "/users/foo%2Fbar",
+		},
+		{
+			name:            "space",
+			path:            "/users/foo%20bar",
+			wantPath:        "/users/foo bar",
+			wantRawPath:     "/users/foo%20bar",
+			wantEscapedPath: "/users/foo%20bar",
+		},
+		{
+			name:            "unicode",
+			path:            "/users/%E3%81%82",
+			wantPath:        "/users/あ",
+			wantRawPath:     "/users/%E3%81%82",
+			wantEscapedPath: "/users/%E3%81%82",
+		},
+		{
+			name:            "empty segment",
+			path:            "/users//foo",
+			wantPath:        "/users//foo",
+			wantRawPath:     "/users//foo",
+			wantEscapedPath: "/users//foo",
+		},
+		{
+			name:            "plain",
+			path:            "/users/foo",
+			wantPath:        "/users/foo",
+			wantRawPath:     "/users/foo",
+			wantEscapedPath: "/users/foo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := json.Marshal(RequestV1{
+				HTTPMethod: "GET",
+				Path:       tt.path,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, err := NewRequest(event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := req.URL.Path; got != tt.wantPath {
+				t.Errorf("URL.Path = %q, want %q", got, tt.wantPath)
+			}
+			if got := req.URL.RawPath; got != tt.wantRawPath {
+				t.Errorf("URL.RawPath = %q, want %q", got, tt.wantRawPath)
+			}
+			if got := req.URL.EscapedPath(); got != tt.wantEscapedPath {
+				t.Errorf("URL.EscapedPath() = %q, want %q", got, tt.wantEscapedPath)
+			}
+		})
+	}
+}
+
+func TestRequestV2PathEscaping(t *testing.T) {
+	tests := []struct {
+		name            string
+		rawPath         string
+		wantPath        string
+		wantRawPath     string
+		wantEscapedPath string
+	}{
+		{
+			name:            "encoded slash",
+			rawPath:         "/users/foo%2Fbar",
+			wantPath:        "/users/foo/bar",
+			wantRawPath:     "/users/foo%2Fbar",
+			wantEscapedPath: "/users/foo%2Fbar",
+		},
+		{
+			name:            "space",
+			rawPath:         "/users/foo%20bar",
+			wantPath:        "/users/foo bar",
+			wantRawPath:     "/users/foo%20bar",
+			wantEscapedPath: "/users/foo%20bar",
+		},
+		{
+			name:            "unicode",
+			rawPath:         "/users/%E3%81%82",
+			wantPath:        "/users/あ",
+			wantRawPath:     "/users/%E3%81%82",
+			wantEscapedPath: "/users/%E3%81%82",
+		},
+		{
+			name:            "empty segment",
+			rawPath:         "/users//foo",
+			wantPath:        "/users//foo",
+			wantRawPath:     "/users//foo",
+			wantEscapedPath: "/users//foo",
+		},
+		{
+			name:            "plain",
+			rawPath:         "/users/foo",
+			wantPath:        "/users/foo",
+			wantRawPath:     "/users/foo",
+			wantEscapedPath: "/users/foo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := json.Marshal(RequestV2{
+				Version: "2.0",
+				RawPath: tt.rawPath,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, err := NewRequest(event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := req.URL.Path; got != tt.wantPath {
+				t.Errorf("URL.Path = %q, want %q", got, tt.wantPath)
+			}
+			if got := req.URL.RawPath; got != tt.wantRawPath {
+				t.Errorf("URL.RawPath = %q, want %q", got, tt.wantRawPath)
+			}
+			if got := req.URL.EscapedPath(); got != tt.wantEscapedPath {
+				t.Errorf("URL.EscapedPath() = %q, want %q", got, tt.wantEscapedPath)
+			}
+		})
+	}
+}
+
+func TestRequestV1InvalidPathReturnsError(t *testing.T) {
+	event, err := json.Marshal(RequestV1{
+		HTTPMethod: "GET",
+		Path:       "/users/%zz",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRequest(event); err == nil {
+		t.Error("expected an error for a malformed percent-escape, got nil")
+	}
+}
+
+func TestRequestALBPathEscaping(t *testing.T) {
+	event, err := json.Marshal(RequestALB{
+		HTTPMethod: "GET",
+		Path:       "/users/foo%2Fbar",
+		RequestContext: RequestContextALB{
+			ELB: struct {
+				TargetGroupArn string `json:"targetGroupArn"`
+			}{TargetGroupArn: "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/test/abc"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := NewRequest(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := req.URL.Path, "/users/foo/bar"; got != want {
+		t.Errorf("URL.Path = %q, want %q", got, want)
+	}
+	if got, want := req.URL.EscapedPath(), "/users/foo%2Fbar"; got != want {
+		t.Errorf("URL.EscapedPath() = %q, want %q", got, want)
+	}
+}
+
+func TestRequestALBInvalidPathReturnsError(t *testing.T) {
+	event, err := json.Marshal(RequestALB{
+		HTTPMethod: "GET",
+		Path:       "/users/%zz",
+		RequestContext: RequestContextALB{
+			ELB: struct {
+				TargetGroupArn string `json:"targetGroupArn"`
+			}{TargetGroupArn: "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/test/abc"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req, err := NewRequest(event); err == nil {
+		t.Errorf("expected an error for a malformed percent-escape, got nil request %v", req)
+	}
+}
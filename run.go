@@ -0,0 +1,306 @@
+package ridge
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+)
+
+const runtimeAPIVersion = "2018-06-01"
+
+// Run starts serving h. Inside an AWS Lambda execution environment
+// (detected via the AWS_LAMBDA_RUNTIME_API environment variable) it polls
+// the Lambda Runtime API for invocations and dispatches each one to h,
+// buffering the response into the single JSON envelope API Gateway v1/v2
+// and ALB expect. Outside Lambda it starts a local net/http server
+// listening on address, so the same binary can be run and tested locally.
+//
+// If AWS_LAMBDA_FUNCTION_RESPONSE_STREAM_MODE is set, as it is for
+// functions whose Function URL is configured with the RESPONSE_STREAM
+// invoke mode, Run behaves like RunStream instead.
+func Run(address string, h http.Handler) error {
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") == "" {
+		return http.ListenAndServe(address, h)
+	}
+	if os.Getenv("AWS_LAMBDA_FUNCTION_RESPONSE_STREAM_MODE") != "" {
+		return RunStream(h)
+	}
+	return runLambda(h, false)
+}
+
+// RunStream is like Run but serves Lambda invocations through the streaming
+// response path used by InvokeWithResponseStream and Function URLs
+// configured with the RESPONSE_STREAM invoke mode, so handlers can flush
+// output incrementally (e.g. Server-Sent Events or chunked responses).
+// Invocations whose event is not capable of carrying a streamed response
+// (API Gateway v1/v2, ALB) transparently fall back to the buffered
+// envelope, so the same handler binary works behind either deployment.
+func RunStream(h http.Handler) error {
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") == "" {
+		return fmt.Errorf("ridge: RunStream must be run inside a Lambda execution environment")
+	}
+	return runLambda(h, true)
+}
+
+func runLambda(h http.Handler, stream bool) error {
+	api := os.Getenv("AWS_LAMBDA_RUNTIME_API")
+	client := &http.Client{}
+	for {
+		requestID, event, err := nextInvocation(client, api)
+		if err != nil {
+			return err
+		}
+		if err := serveInvocation(client, api, requestID, event, h, stream); err != nil {
+			if err := postInvocationError(client, api, requestID, err); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func nextInvocation(client *http.Client, api string) (requestID string, event []byte, err error) {
+	resp, err := client.Get(fmt.Sprintf("http://%s/%s/runtime/invocation/next", api, runtimeAPIVersion))
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+	event, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return "", nil, err
+	}
+	return resp.Header.Get("Lambda-Runtime-Aws-Request-Id"), event, nil
+}
+
+func postInvocationError(client *http.Client, api, requestID string, invocationErr error) error {
+	body, _ := json.Marshal(map[string]string{
+		"errorMessage": invocationErr.Error(),
+		"errorType":    "HandlerError",
+	})
+	url := fmt.Sprintf("http://%s/%s/runtime/invocation/%s/error", api, runtimeAPIVersion, requestID)
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// streamingCapable reports whether a payload shape is able to carry a streamed
+// response: Function URL invocations (version 2.0 events whose domain name is
+// a ".lambda-url." domain) can, while API Gateway REST (v1.0) and HTTP API
+// (v2.0) integrations and ALB target groups cannot.
+func streamingCapable(event []byte) bool {
+	var r struct {
+		Version        string `json:"version"`
+		RequestContext struct {
+			DomainName string          `json:"domainName"`
+			ELB        json.RawMessage `json:"elb"`
+		} `json:"requestContext"`
+	}
+	if err := json.Unmarshal(event, &r); err != nil {
+		return false
+	}
+	return r.Version == "2.0" && r.RequestContext.ELB == nil &&
+		strings.Contains(r.RequestContext.DomainName, ".lambda-url.")
+}
+
+func serveInvocation(client *http.Client, api, requestID string, event []byte, h http.Handler, stream bool) error {
+	req, err := NewRequest(event)
+	if err != nil {
+		return err
+	}
+
+	if stream && streamingCapable(event) {
+		return serveStreamingInvocation(client, api, requestID, req, h)
+	}
+	return serveBufferedInvocation(client, api, requestID, event, req, h)
+}
+
+func serveBufferedInvocation(client *http.Client, api, requestID string, event []byte, req *http.Request, h http.Handler) error {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	body, contentType, err := marshalBufferedResponse(event, rec)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("http://%s/%s/runtime/invocation/%s/response", api, runtimeAPIVersion, requestID)
+	httpReq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// marshalBufferedResponse builds the JSON envelope expected back by
+// whichever integration produced event: RequestV1, RequestV2, or RequestALB.
+func marshalBufferedResponse(event []byte, rec *httptest.ResponseRecorder) ([]byte, string, error) {
+	body := rec.Body.Bytes()
+	isBase64 := isBinaryContentType(rec.Header().Get("Content-Type"))
+	encodedBody := string(body)
+	if isBase64 {
+		encodedBody = base64.StdEncoding.EncodeToString(body)
+	}
+
+	var kind struct {
+		Version        string `json:"version"`
+		RequestContext struct {
+			ELB json.RawMessage `json:"elb"`
+		} `json:"requestContext"`
+		MultiValueHeaders json.RawMessage `json:"multiValueHeaders"`
+	}
+	if err := json.Unmarshal(event, &kind); err != nil {
+		return nil, "", err
+	}
+
+	switch {
+	case kind.RequestContext.ELB != nil:
+		resp := ResponseALB{
+			StatusCode:        rec.Code,
+			StatusDescription: fmt.Sprintf("%d %s", rec.Code, http.StatusText(rec.Code)),
+			Body:              encodedBody,
+			IsBase64Encoded:   isBase64,
+		}
+		if len(kind.MultiValueHeaders) > 0 && string(kind.MultiValueHeaders) != "null" {
+			resp.MultiValueHeaders = rec.Result().Header
+		} else {
+			resp.Headers = flattenHeader(rec.Header())
+		}
+		b, err := json.Marshal(resp)
+		return b, "application/json", err
+	case kind.Version == "2.0":
+		resp := struct {
+			StatusCode      int               `json:"statusCode"`
+			Headers         map[string]string `json:"headers,omitempty"`
+			Cookies         []string          `json:"cookies,omitempty"`
+			Body            string            `json:"body"`
+			IsBase64Encoded bool              `json:"isBase64Encoded"`
+		}{
+			StatusCode:      rec.Code,
+			Headers:         flattenHeader(rec.Header()),
+			Cookies:         rec.Result().Header["Set-Cookie"],
+			Body:            encodedBody,
+			IsBase64Encoded: isBase64,
+		}
+		b, err := json.Marshal(resp)
+		return b, "application/json", err
+	default:
+		resp := struct {
+			StatusCode      int               `json:"statusCode"`
+			Headers         map[string]string `json:"headers,omitempty"`
+			Body            string            `json:"body"`
+			IsBase64Encoded bool              `json:"isBase64Encoded"`
+		}{
+			StatusCode:      rec.Code,
+			Headers:         flattenHeader(rec.Header()),
+			Body:            encodedBody,
+			IsBase64Encoded: isBase64,
+		}
+		b, err := json.Marshal(resp)
+		return b, "application/json", err
+	}
+}
+
+func flattenHeader(h http.Header) map[string]string {
+	flat := make(map[string]string, len(h))
+	for key := range h {
+		flat[key] = h.Get(key)
+	}
+	return flat
+}
+
+// streamResponseWriter implements http.ResponseWriter (and http.Flusher) on
+// top of the Lambda streaming invocation response body, following the wire
+// format InvokeWithResponseStream expects: an 8-byte prelude holding the
+// length of the JSON metadata, the metadata itself, an 8-byte NUL separator,
+// and finally the raw response body, flushed as the handler writes to it.
+type streamResponseWriter struct {
+	w           io.Writer
+	header      http.Header
+	wroteHeader bool
+	statusCode  int
+}
+
+func (s *streamResponseWriter) Header() http.Header {
+	return s.header
+}
+
+func (s *streamResponseWriter) WriteHeader(statusCode int) {
+	if s.wroteHeader {
+		return
+	}
+	s.wroteHeader = true
+	s.statusCode = statusCode
+
+	metadata, _ := json.Marshal(struct {
+		StatusCode int               `json:"statusCode"`
+		Headers    map[string]string `json:"headers,omitempty"`
+		Cookies    []string          `json:"cookies,omitempty"`
+	}{
+		StatusCode: statusCode,
+		Headers:    flattenHeader(s.header),
+		Cookies:    s.header["Set-Cookie"],
+	})
+
+	var prelude [8]byte
+	binary.BigEndian.PutUint64(prelude[:], uint64(len(metadata)))
+	s.w.Write(prelude[:])
+	s.w.Write(metadata)
+	s.w.Write(make([]byte, 8)) // NUL separator
+}
+
+func (s *streamResponseWriter) Write(p []byte) (int, error) {
+	if !s.wroteHeader {
+		s.WriteHeader(http.StatusOK)
+	}
+	return s.w.Write(p)
+}
+
+// Flush is a no-op beyond ensuring the header is written: s.w is an
+// io.PipeWriter, so every Write already blocks until the Lambda Runtime API
+// HTTP client has read the bytes, making writes inherently unbuffered.
+func (s *streamResponseWriter) Flush() {
+	if !s.wroteHeader {
+		s.WriteHeader(http.StatusOK)
+	}
+}
+
+func serveStreamingInvocation(client *http.Client, api, requestID string, req *http.Request, h http.Handler) error {
+	url := fmt.Sprintf("http://%s/%s/runtime/invocation/%s/response", api, runtimeAPIVersion, requestID)
+
+	pr, pw := io.Pipe()
+	sw := &streamResponseWriter{w: pw, header: make(http.Header)}
+
+	errc := make(chan error, 1)
+	go func() {
+		defer pw.Close()
+		h.ServeHTTP(sw, req)
+		errc <- nil
+	}()
+
+	httpReq, err := http.NewRequest(http.MethodPut, url, pr)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/vnd.awslambda.http-integration-response")
+	httpReq.Header.Set("Transfer-Encoding", "chunked")
+	httpReq.Header.Set("Lambda-Runtime-Function-Response-Mode", "streaming")
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return <-errc
+}
@@ -0,0 +1,118 @@
+package ridge
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStreamingCapable(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		want  bool
+	}{
+		{
+			name:  "http api v2",
+			event: `{"version":"2.0"}`,
+			want:  false,
+		},
+		{
+			name:  "function url",
+			event: `{"version":"2.0","requestContext":{"domainName":"abc123.lambda-url.us-east-1.on.aws"}}`,
+			want:  true,
+		},
+		{
+			name:  "rest api v1",
+			event: `{"version":"1.0"}`,
+			want:  false,
+		},
+		{
+			name:  "alb",
+			event: `{"requestContext":{"elb":{"targetGroupArn":"arn"}}}`,
+			want:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := streamingCapable([]byte(tt.event)); got != tt.want {
+				t.Errorf("streamingCapable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalBufferedResponseALBSingleValue(t *testing.T) {
+	event := []byte(`{"requestContext":{"elb":{"targetGroupArn":"arn"}}}`)
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Type", "text/plain")
+	rec.WriteHeader(200)
+	rec.WriteString("hello")
+
+	body, contentType, err := marshalBufferedResponse(event, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "application/json" {
+		t.Errorf("contentType = %q, want application/json", contentType)
+	}
+	var resp ResponseALB
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Headers == nil {
+		t.Error("Headers is nil, want populated")
+	}
+	if resp.MultiValueHeaders != nil {
+		t.Errorf("MultiValueHeaders = %v, want nil for a single-value request", resp.MultiValueHeaders)
+	}
+}
+
+func TestMarshalBufferedResponseALBMultiValue(t *testing.T) {
+	event := []byte(`{"requestContext":{"elb":{"targetGroupArn":"arn"}},"multiValueHeaders":{"X-Test":["a"]}}`)
+	rec := httptest.NewRecorder()
+	rec.Header().Add("X-Custom", "a")
+	rec.Header().Add("X-Custom", "b")
+	rec.WriteHeader(200)
+	rec.WriteString("hello")
+
+	body, _, err := marshalBufferedResponse(event, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp ResponseALB
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Headers != nil {
+		t.Errorf("Headers = %v, want nil for a multi-value request", resp.Headers)
+	}
+	if got := resp.MultiValueHeaders["X-Custom"]; len(got) != 2 {
+		t.Errorf("MultiValueHeaders[X-Custom] = %v, want 2 values", got)
+	}
+}
+
+func TestMarshalBufferedResponseV2(t *testing.T) {
+	event := []byte(`{"version":"2.0"}`)
+	rec := httptest.NewRecorder()
+	rec.WriteHeader(201)
+	rec.WriteString("created")
+
+	body, _, err := marshalBufferedResponse(event, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp struct {
+		StatusCode int    `json:"statusCode"`
+		Body       string `json:"body"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 201 {
+		t.Errorf("StatusCode = %d, want 201", resp.StatusCode)
+	}
+	if resp.Body != "created" {
+		t.Errorf("Body = %q, want %q", resp.Body, "created")
+	}
+}
@@ -0,0 +1,316 @@
+package ridge
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Credentials is an AWS Signature Version 4 credential pair.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// CredentialsProvider resolves the Credentials for a given access key ID so
+// SigV4Verifier can recompute the signature of an incoming request. It
+// should return an error (or the zero Credentials) for unknown access keys.
+type CredentialsProvider interface {
+	GetCredentials(accessKeyID string) (Credentials, error)
+}
+
+// SigV4Verifier verifies that an incoming request was signed with AWS
+// Signature Version 4, as done by clients calling API Gateway or a Lambda
+// Function URL configured with "AWS_IAM" authorization. The signature,
+// access key ID, and signed headers all travel with the request itself (in
+// the Authorization, X-Amz-Date, and X-Amz-Security-Token headers), so
+// verification can happen entirely inside the Lambda function.
+type SigV4Verifier struct {
+	// Credentials resolves the secret access key for the access key ID
+	// found in the Authorization header.
+	Credentials CredentialsProvider
+
+	// Region and Service are the values the signature's credential scope
+	// must match, e.g. "us-east-1" and "execute-api".
+	Region  string
+	Service string
+
+	// ClockSkew is the allowed difference between X-Amz-Date and the
+	// current time. Defaults to 15 minutes if zero.
+	ClockSkew time.Duration
+
+	// ValidateSessionToken, if set, is called with the X-Amz-Security-Token
+	// header (if present) so callers can reject expired or revoked STS
+	// session tokens. It is not called when the header is absent.
+	ValidateSessionToken func(sessionToken string) error
+}
+
+// Wrap returns an http.Handler that verifies the SigV4 signature of each
+// request before delegating to h, responding 403 Forbidden on mismatch.
+func (v *SigV4Verifier) Wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := v.Verify(r); err != nil {
+			http.Error(w, fmt.Sprintf("sigv4: %s", err), http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Verify recomputes the AWS Signature Version 4 signature of r and compares
+// it against the one supplied in the Authorization header, returning an
+// error if they don't match.
+func (v *SigV4Verifier) Verify(r *http.Request) error {
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		return fmt.Errorf("missing Authorization header")
+	}
+	scope, signedHeaders, signature, err := parseAuthorizationHeader(auth)
+	if err != nil {
+		return err
+	}
+	accessKeyID, date, region, service, err := parseCredentialScope(scope)
+	if err != nil {
+		return err
+	}
+	if v.Region != "" && region != v.Region {
+		return fmt.Errorf("unexpected region %q in credential scope", region)
+	}
+	if v.Service != "" && service != v.Service {
+		return fmt.Errorf("unexpected service %q in credential scope", service)
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	if amzDate == "" {
+		return fmt.Errorf("missing X-Amz-Date header")
+	}
+	reqTime, err := time.Parse("20060102T150405Z", amzDate)
+	if err != nil {
+		return fmt.Errorf("invalid X-Amz-Date header: %w", err)
+	}
+	skew := v.ClockSkew
+	if skew == 0 {
+		skew = 15 * time.Minute
+	}
+	if d := time.Since(reqTime); d > skew || d < -skew {
+		return fmt.Errorf("X-Amz-Date is outside the allowed clock skew")
+	}
+
+	if sessionToken := r.Header.Get("X-Amz-Security-Token"); sessionToken != "" && v.ValidateSessionToken != nil {
+		if err := v.ValidateSessionToken(sessionToken); err != nil {
+			return fmt.Errorf("invalid session token: %w", err)
+		}
+	}
+
+	creds, err := v.Credentials.GetCredentials(accessKeyID)
+	if err != nil {
+		return fmt.Errorf("unknown access key: %w", err)
+	}
+
+	payloadHash, err := payloadHash(r)
+	if err != nil {
+		return err
+	}
+	canonicalRequest, err := canonicalRequest(r, signedHeaders, payloadHash, service)
+	if err != nil {
+		return err
+	}
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(sha256Sum([]byte(canonicalRequest))),
+	}, "\n")
+
+	signingKey := signingKey(creds.SecretAccessKey, date, region, service)
+	expected := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// parseAuthorizationHeader splits the AWS4-HMAC-SHA256 Authorization header
+// into its credential scope, signed header list, and signature.
+func parseAuthorizationHeader(auth string) (scope string, signedHeaders []string, signature string, err error) {
+	const prefix = "AWS4-HMAC-SHA256 "
+	if !strings.HasPrefix(auth, prefix) {
+		return "", nil, "", fmt.Errorf("unsupported Authorization scheme")
+	}
+	fields := map[string]string{}
+	for _, part := range strings.Split(strings.TrimPrefix(auth, prefix), ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return "", nil, "", fmt.Errorf("malformed Authorization header")
+		}
+		fields[kv[0]] = kv[1]
+	}
+	scope, ok := fields["Credential"]
+	if !ok {
+		return "", nil, "", fmt.Errorf("missing Credential in Authorization header")
+	}
+	signed, ok := fields["SignedHeaders"]
+	if !ok {
+		return "", nil, "", fmt.Errorf("missing SignedHeaders in Authorization header")
+	}
+	signature, ok = fields["Signature"]
+	if !ok {
+		return "", nil, "", fmt.Errorf("missing Signature in Authorization header")
+	}
+	return scope, strings.Split(signed, ";"), signature, nil
+}
+
+// parseCredentialScope splits "AKID/20230101/us-east-1/execute-api/aws4_request".
+func parseCredentialScope(scope string) (accessKeyID, date, region, service string, err error) {
+	parts := strings.Split(scope, "/")
+	if len(parts) != 5 || parts[4] != "aws4_request" {
+		return "", "", "", "", fmt.Errorf("malformed credential scope %q", scope)
+	}
+	return parts[0], parts[1], parts[2], parts[3], nil
+}
+
+// payloadHash returns the hex-encoded SHA-256 hash of the request body,
+// honoring the "UNSIGNED-PAYLOAD" sentinel API Gateway / Lambda clients may
+// send, and restores r.Body so downstream handlers can still read it. Any
+// other value of X-Amz-Content-Sha256 is ignored: the hash is always
+// recomputed from the actual reconstructed body, since trusting a
+// caller-supplied hash would let a client sign one payload and deliver
+// another.
+func payloadHash(r *http.Request) (string, error) {
+	if h := r.Header.Get("X-Amz-Content-Sha256"); h == "UNSIGNED-PAYLOAD" {
+		return h, nil
+	}
+	if r.Body == nil {
+		return hex.EncodeToString(sha256Sum(nil)), nil
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return "", err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return hex.EncodeToString(sha256Sum(body)), nil
+}
+
+// canonicalRequest builds the SigV4 canonical request string for r, as
+// defined by https://docs.aws.amazon.com/general/latest/gr/sigv4-create-canonical-request.html.
+func canonicalRequest(r *http.Request, signedHeaders []string, payloadHash, service string) (string, error) {
+	uri := r.URL.EscapedPath()
+	if uri == "" {
+		uri = "/"
+	}
+	canonicalURI, err := canonicalURI(uri, service)
+	if err != nil {
+		return "", err
+	}
+	canonicalQuery := canonicalQueryString(r.URL.Query())
+
+	var headers strings.Builder
+	for _, name := range signedHeaders {
+		value := headerValue(r, name)
+		headers.WriteString(strings.ToLower(name))
+		headers.WriteByte(':')
+		headers.WriteString(collapseWhitespace(value))
+		headers.WriteByte('\n')
+	}
+
+	return strings.Join([]string{
+		r.Method,
+		canonicalURI,
+		canonicalQuery,
+		headers.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n"), nil
+}
+
+// headerValue returns the value ridge should sign for a SignedHeaders entry,
+// special-casing "host" since it is promoted out of r.Header onto r.Host.
+func headerValue(r *http.Request, name string) string {
+	if strings.EqualFold(name, "host") {
+		return r.Host
+	}
+	return strings.Join(r.Header.Values(name), ",")
+}
+
+func collapseWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// canonicalURI percent-encodes uri using the AWS REST escaping table, except
+// for the Amazon S3 service, whose object keys must be encoded exactly once.
+func canonicalURI(uri, service string) (string, error) {
+	segments := strings.Split(uri, "/")
+	for i, seg := range segments {
+		decoded, err := url.PathUnescape(seg)
+		if err != nil {
+			return "", err
+		}
+		if service == "s3" {
+			segments[i] = awsEscape(decoded)
+		} else {
+			segments[i] = awsEscape(awsEscape(decoded))
+		}
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+func awsEscape(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func canonicalQueryString(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func signingKey(secret, date, region, service string) []byte {
+	kDate := hmacSHA256([]byte("AWS4"+secret), date)
+	kRegion := hmacSHA256(kDate, region)
+	kService := hmacSHA256(kRegion, service)
+	return hmacSHA256(kService, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Sum(data []byte) []byte {
+	h := sha256.Sum256(data)
+	return h[:]
+}
@@ -0,0 +1,134 @@
+package ridge
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type staticCredentialsProvider struct {
+	creds Credentials
+}
+
+func (p staticCredentialsProvider) GetCredentials(accessKeyID string) (Credentials, error) {
+	if accessKeyID != p.creds.AccessKeyID {
+		return Credentials{}, fmt.Errorf("unknown access key %q", accessKeyID)
+	}
+	return p.creds, nil
+}
+
+// signRequest signs r as an AWS SigV4 client would, setting the
+// Authorization and X-Amz-Date headers in place.
+func signRequest(t *testing.T, r *http.Request, creds Credentials, region, service string, body []byte, signedHeaders []string, when time.Time) {
+	t.Helper()
+	amzDate := when.Format("20060102T150405Z")
+	date := when.Format("20060102")
+	r.Header.Set("X-Amz-Date", amzDate)
+
+	hash := hex.EncodeToString(sha256Sum(body))
+	creq, err := canonicalRequest(r, signedHeaders, hash, service)
+	if err != nil {
+		t.Fatalf("canonicalRequest: %v", err)
+	}
+	scope := strings.Join([]string{creds.AccessKeyID, date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(sha256Sum([]byte(creq))),
+	}, "\n")
+	key := signingKey(creds.SecretAccessKey, date, region, service)
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	r.Header.Set("Authorization", "AWS4-HMAC-SHA256 "+strings.Join([]string{
+		"Credential=" + scope,
+		"SignedHeaders=" + strings.Join(signedHeaders, ";"),
+		"Signature=" + signature,
+	}, ","))
+}
+
+func TestSigV4VerifierValidSignature(t *testing.T) {
+	creds := Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}
+	body := []byte(`{"hello":"world"}`)
+
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/foo/bar?a=1", bytes.NewReader(body))
+	r.Host = "example.com"
+	signRequest(t, r, creds, "us-east-1", "execute-api", body, []string{"host", "x-amz-date"}, time.Now())
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	v := &SigV4Verifier{
+		Credentials: staticCredentialsProvider{creds: creds},
+		Region:      "us-east-1",
+		Service:     "execute-api",
+	}
+	if err := v.Verify(r); err != nil {
+		t.Fatalf("Verify() = %v, want nil", err)
+	}
+}
+
+func TestSigV4VerifierTamperedBodyRejected(t *testing.T) {
+	creds := Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}
+	body := []byte(`{"hello":"world"}`)
+
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/foo/bar?a=1", bytes.NewReader(body))
+	r.Host = "example.com"
+	signRequest(t, r, creds, "us-east-1", "execute-api", body, []string{"host", "x-amz-date"}, time.Now())
+
+	// An attacker swaps in a different body after the signature was computed.
+	r.Body = ioutil.NopCloser(bytes.NewReader([]byte(`{"hello":"attacker"}`)))
+
+	v := &SigV4Verifier{
+		Credentials: staticCredentialsProvider{creds: creds},
+		Region:      "us-east-1",
+		Service:     "execute-api",
+	}
+	if err := v.Verify(r); err == nil {
+		t.Fatal("Verify() = nil, want an error for a tampered body")
+	}
+}
+
+func TestSigV4VerifierWrongSecretRejected(t *testing.T) {
+	signingCreds := Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}
+	verifyingCreds := Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "different-secret"}
+	body := []byte(`{"hello":"world"}`)
+
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/foo/bar", bytes.NewReader(body))
+	r.Host = "example.com"
+	signRequest(t, r, signingCreds, "us-east-1", "execute-api", body, []string{"host", "x-amz-date"}, time.Now())
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	v := &SigV4Verifier{
+		Credentials: staticCredentialsProvider{creds: verifyingCreds},
+		Region:      "us-east-1",
+		Service:     "execute-api",
+	}
+	if err := v.Verify(r); err == nil {
+		t.Fatal("Verify() = nil, want an error for a signature made with a different secret")
+	}
+}
+
+func TestSigV4VerifierExpiredClockSkewRejected(t *testing.T) {
+	creds := Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}
+	body := []byte(`{}`)
+
+	r := httptest.NewRequest(http.MethodPost, "http://example.com/foo", bytes.NewReader(body))
+	r.Host = "example.com"
+	signRequest(t, r, creds, "us-east-1", "execute-api", body, []string{"host", "x-amz-date"}, time.Now().Add(-time.Hour))
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	v := &SigV4Verifier{
+		Credentials: staticCredentialsProvider{creds: creds},
+		Region:      "us-east-1",
+		Service:     "execute-api",
+		ClockSkew:   time.Minute,
+	}
+	if err := v.Verify(r); err == nil {
+		t.Fatal("Verify() = nil, want an error for a timestamp far outside ClockSkew")
+	}
+}